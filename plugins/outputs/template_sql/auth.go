@@ -0,0 +1,235 @@
+package template_sql
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/ClickHouse/clickhouse-go"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+)
+
+// Supported values for auth_method.
+const (
+	authMethodPassword     = "sqlpassword"
+	authMethodKerberos     = "kerberos"
+	authMethodAzureManaged = "azure_ad_managed_identity"
+)
+
+// buildDSN assembles a driver-specific data_source_name from the plugin's
+// structured connection, auth_method and TLS settings. It is only called
+// when the user has left data_source_name empty.
+func (p *SQL) buildDSN() (string, error) {
+	switch p.Driver {
+	case "mysql":
+		return p.buildMySQLDSN()
+	case "pgx":
+		return p.buildPgxDSN()
+	case "mssql":
+		return p.buildMSSQLDSN()
+	case "clickhouse":
+		return p.buildClickHouseDSN()
+	case "snowflake":
+		return p.buildSnowflakeDSN()
+	default:
+		return "", fmt.Errorf("building data_source_name from structured fields is not supported for driver %q", p.Driver)
+	}
+}
+
+// tlsConfig builds a *tls.Config from the TLS options, or returns nil if
+// none were set. mysql and clickhouse both register *tls.Config values into
+// a package-level registry keyed by a string that appears nowhere else in
+// the connection DSN but that name; buildMySQLDSN/buildClickHouseDSN must
+// mint a fresh key per call (see "custom-"+uuid.NewV7()) rather than reusing
+// a constant, or a second instance with a different cert would silently
+// overwrite the first instance's entry.
+func (p *SQL) tlsConfig() (*tls.Config, error) {
+	if p.TLSCA == "" && p.TLSCert == "" && !p.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{
+		InsecureSkipVerify: p.InsecureSkipVerify,
+		ServerName:         p.TLSServerName,
+	}
+
+	if p.TLSCA != "" {
+		ca, err := os.ReadFile(p.TLSCA)
+		if err != nil {
+			return nil, fmt.Errorf("reading tls_ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("tls_ca does not contain a valid certificate")
+		}
+		cfg.RootCAs = pool
+	}
+
+	if p.TLSCert != "" || p.TLSKey != "" {
+		cert, err := tls.LoadX509KeyPair(p.TLSCert, p.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("loading tls_cert/tls_key: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+func (p *SQL) buildMySQLDSN() (string, error) {
+	cfg := mysqldriver.NewConfig()
+	cfg.User = p.Username
+	cfg.Passwd = p.Password
+	cfg.Net = "tcp"
+	cfg.Addr = fmt.Sprintf("%s:%d", p.Server, p.Port)
+	cfg.DBName = p.Database
+
+	tlsCfg, err := p.tlsConfig()
+	if err != nil {
+		return "", err
+	}
+	if tlsCfg != nil {
+		tlsID := "custom-" + uuid.Must(uuid.NewV7()).String()
+		if err := mysqldriver.RegisterTLSConfig(tlsID, tlsCfg); err != nil {
+			return "", fmt.Errorf("registering mysql tls config: %w", err)
+		}
+		cfg.TLSConfig = tlsID
+	}
+
+	if p.AuthMethod == authMethodKerberos {
+		return "", fmt.Errorf("auth_method = %q is not supported by the mysql driver", authMethodKerberos)
+	}
+
+	return cfg.FormatDSN(), nil
+}
+
+func (p *SQL) buildPgxDSN() (string, error) {
+	dsn := &url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(p.Username, p.Password),
+		Host:   fmt.Sprintf("%s:%d", p.Server, p.Port),
+		Path:   "/" + p.Database,
+	}
+
+	values := url.Values{}
+
+	switch {
+	case p.InsecureSkipVerify:
+		values.Set("sslmode", "require")
+	case p.TLSCA != "" || p.TLSCert != "":
+		values.Set("sslmode", "verify-full")
+	default:
+		values.Set("sslmode", "prefer")
+	}
+	if p.TLSCA != "" {
+		values.Set("sslrootcert", p.TLSCA)
+	}
+	if p.TLSCert != "" {
+		values.Set("sslcert", p.TLSCert)
+	}
+	if p.TLSKey != "" {
+		values.Set("sslkey", p.TLSKey)
+	}
+
+	switch p.AuthMethod {
+	case "", authMethodPassword:
+		// nothing further to do
+	case authMethodAzureManaged:
+		values.Set("sslmode", "require")
+	default:
+		return "", fmt.Errorf("auth_method = %q is not supported by the pgx driver", p.AuthMethod)
+	}
+
+	dsn.RawQuery = values.Encode()
+	return dsn.String(), nil
+}
+
+func (p *SQL) buildMSSQLDSN() (string, error) {
+	dsn := &url.URL{
+		Scheme: "sqlserver",
+		Host:   fmt.Sprintf("%s:%d", p.Server, p.Port),
+	}
+
+	values := url.Values{}
+	values.Set("database", p.Database)
+
+	switch p.AuthMethod {
+	case "", authMethodPassword:
+		dsn.User = url.UserPassword(p.Username, p.Password)
+	case authMethodKerberos:
+		values.Set("authenticator", "krb5")
+		if p.Krb5ConfFile != "" {
+			values.Set("krb5-configfile", p.Krb5ConfFile)
+		}
+		if p.Krb5Realm != "" {
+			values.Set("krb5-realm", p.Krb5Realm)
+		}
+		if p.Krb5KeytabFile != "" {
+			values.Set("krb5-keytabfile", p.Krb5KeytabFile)
+		}
+		if p.Krb5Username != "" {
+			values.Set("krb5-username", p.Krb5Username)
+		}
+	case authMethodAzureManaged:
+		values.Set("authenticator", "ActiveDirectoryMSI")
+	default:
+		return "", fmt.Errorf("auth_method = %q is not supported by the mssql driver", p.AuthMethod)
+	}
+
+	if p.TLSCA != "" || p.TLSCert != "" || p.InsecureSkipVerify {
+		values.Set("encrypt", "true")
+		if p.InsecureSkipVerify {
+			values.Set("trustservercertificate", "true")
+		}
+		if p.TLSCA != "" {
+			values.Set("certificate", p.TLSCA)
+		}
+		if p.TLSServerName != "" {
+			values.Set("hostnameincertificate", p.TLSServerName)
+		}
+	}
+
+	dsn.RawQuery = values.Encode()
+	return dsn.String(), nil
+}
+
+func (p *SQL) buildClickHouseDSN() (string, error) {
+	values := url.Values{}
+	values.Set("username", p.Username)
+	values.Set("password", p.Password)
+	values.Set("database", p.Database)
+
+	tlsCfg, err := p.tlsConfig()
+	if err != nil {
+		return "", err
+	}
+	if tlsCfg != nil {
+		tlsID := "custom-" + uuid.Must(uuid.NewV7()).String()
+		if err := clickhouse.RegisterTLSConfig(tlsID, tlsCfg); err != nil {
+			return "", fmt.Errorf("registering clickhouse tls config: %w", err)
+		}
+		values.Set("secure", "true")
+		values.Set("tls_config", tlsID)
+	}
+
+	if p.AuthMethod != "" && p.AuthMethod != authMethodPassword {
+		return "", fmt.Errorf("auth_method = %q is not supported by the clickhouse driver", p.AuthMethod)
+	}
+
+	return fmt.Sprintf("tcp://%s:%d?%s", p.Server, p.Port, values.Encode()), nil
+}
+
+func (p *SQL) buildSnowflakeDSN() (string, error) {
+	if p.AuthMethod != "" && p.AuthMethod != authMethodPassword {
+		return "", fmt.Errorf("auth_method = %q is not supported by the snowflake driver", p.AuthMethod)
+	}
+
+	dsn := fmt.Sprintf("%s:%s@%s/%s", url.QueryEscape(p.Username), url.QueryEscape(p.Password), p.Server, p.Database)
+	if p.InsecureSkipVerify {
+		dsn += "?insecureMode=true"
+	}
+	return dsn, nil
+}
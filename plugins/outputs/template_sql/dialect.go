@@ -0,0 +1,29 @@
+package template_sql
+
+import "strings"
+
+// dialect captures the handful of per-driver differences the schema-sync
+// subsystem needs; it is intentionally small rather than a full SQL builder.
+type dialect struct {
+	quoteIdent func(name string) string
+}
+
+func dialectFor(driver string) dialect {
+	switch driver {
+	case "mysql":
+		return dialect{quoteIdent: quoteBacktick}
+	case "mssql":
+		return dialect{quoteIdent: quoteBracket}
+	default:
+		// pgx, snowflake and clickhouse all use double-quoted identifiers
+		return dialect{quoteIdent: quoteIdent}
+	}
+}
+
+func quoteBacktick(name string) string {
+	return "`" + strings.ReplaceAll(sanitizeQuoted(name), "`", "``") + "`"
+}
+
+func quoteBracket(name string) string {
+	return "[" + strings.ReplaceAll(sanitizeQuoted(name), "]", "]]") + "]"
+}
@@ -0,0 +1,126 @@
+package template_sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+)
+
+// isTransient reports whether err looks like it was caused by a temporary
+// condition (a dropped connection, a deadlock) rather than a problem with
+// the query or data itself, and is therefore worth retrying.
+func isTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var mysqlErr *mysqldriver.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1213 { // deadlock found
+		return true
+	}
+
+	// Fall back to matching well-known transient error codes/messages that
+	// don't have a typed error available on this code path (e.g. Postgres's
+	// 40P01 deadlock_detected, surfaced differently by pgx and lib/pq).
+	msg := err.Error()
+	for _, needle := range []string{"40P01", "connection reset", "broken pipe", "connection refused", "bad connection"} {
+		if strings.Contains(msg, needle) {
+			return true
+		}
+	}
+	return false
+}
+
+// backoffWithJitter returns an exponentially increasing delay, with up to
+// 50% random jitter added so retrying clients don't all retry in lockstep.
+func backoffWithJitter(base time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	d := base << attempt
+	return d + time.Duration(rand.Int63n(int64(d/2+1)))
+}
+
+// withRetry runs fn up to retry_attempts times in all, retrying transient
+// errors with backoff between attempts and reconnecting first when
+// reconnect_on_error is set. Permanent errors are returned immediately
+// without retrying.
+func (p *SQL) withRetry(fn func() error) error {
+	attempts := p.RetryAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if !isTransient(err) || attempt == attempts-1 {
+			break
+		}
+
+		p.Log.Warnf("transient error, retrying (attempt %d/%d): %v", attempt+1, attempts, err)
+		if p.ReconnectOnError {
+			if rerr := p.reconnect(); rerr != nil {
+				p.Log.Errorf("reconnect failed: %v", rerr)
+			}
+		}
+		time.Sleep(backoffWithJitter(time.Duration(p.RetryBackoff), attempt))
+	}
+	return err
+}
+
+// queryContext returns a context bounded by query_timeout, or an
+// unrestricted one when query_timeout is unset.
+func (p *SQL) queryContext() (context.Context, context.CancelFunc) {
+	if time.Duration(p.QueryTimeout) <= 0 {
+		return context.Background(), func() {}
+	}
+	return context.WithTimeout(context.Background(), time.Duration(p.QueryTimeout))
+}
+
+// reconnect re-establishes the database connection(s) and then closes the
+// ones they replace; it is used
+// after a transient failure when reconnect_on_error is enabled. reconnectMu
+// serializes the whole close-and-reopen sequence, so a Write call and the
+// background flusher hitting the same outage at once don't each dial a
+// replacement pool and race to install it: the loser's pool would otherwise
+// be silently orphaned (never closed, never referenced again) when the
+// winner's assignment overwrote it. connMu is only held for the pointer
+// read/swap themselves, so execBatchOnce/execSingleOnce never see a closed
+// db/pgxPool that openConnections hasn't replaced yet.
+func (p *SQL) reconnect() error {
+	p.reconnectMu.Lock()
+	defer p.reconnectMu.Unlock()
+
+	p.connMu.RLock()
+	db, pgxPool := p.db, p.pgxPool
+	p.connMu.RUnlock()
+
+	if err := p.openConnections(); err != nil {
+		return err
+	}
+	if db != nil {
+		db.Close()
+	}
+	if pgxPool != nil {
+		pgxPool.Close()
+	}
+	return nil
+}
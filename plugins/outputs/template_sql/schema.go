@@ -0,0 +1,118 @@
+package template_sql
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// tableTemplateRegexp substitutes ":name" placeholders in table_template,
+// the same syntax used for query templates.
+var tableTemplateRegexp = regexp.MustCompile(`:[a-zA-Z0-9_]*`)
+
+// renderTable resolves table_template against a metric's values, e.g.
+// "metrics_:metric" becomes "metrics_cpu".
+func (p *SQL) renderTable(valuesMap map[string]interface{}) string {
+	return tableTemplateRegexp.ReplaceAllStringFunc(p.TableTemplate, func(match string) string {
+		key := match[1:]
+		if value, ok := valuesMap[key]; ok {
+			return fmt.Sprintf("%v", value)
+		}
+		return match
+	})
+}
+
+// ensureSchema creates table (if auto_create_table is set and it hasn't been
+// seen before) and adds any newly observed columns (if auto_add_columns is
+// set), tracking what it has already seen in an in-memory cache so it only
+// touches the database once per new table or column.
+func (p *SQL) ensureSchema(table string, valuesMap map[string]interface{}) error {
+	if p.ReadOnly {
+		return nil
+	}
+
+	p.schemaMu.Lock()
+	cols, known := p.schemaCache[table]
+	if !known {
+		p.schemaMu.Unlock()
+
+		if p.AutoCreateTable {
+			if err := p.createTable(table, valuesMap); err != nil {
+				return fmt.Errorf("creating table %q: %w", table, err)
+			}
+		}
+
+		cols = make(map[string]bool, len(valuesMap))
+		for name := range valuesMap {
+			cols[name] = true
+		}
+		p.schemaMu.Lock()
+		p.schemaCache[table] = cols
+		p.schemaMu.Unlock()
+		return nil
+	}
+
+	var missing []string
+	for name := range valuesMap {
+		if !cols[name] {
+			missing = append(missing, name)
+			cols[name] = true
+		}
+	}
+	p.schemaMu.Unlock()
+
+	if len(missing) == 0 || !p.AutoAddColumns {
+		return nil
+	}
+	return p.addColumns(table, missing, valuesMap)
+}
+
+func (p *SQL) columnDatatype(name string, value interface{}) string {
+	if name == "timestamp" {
+		return p.Convert.Timestamp
+	}
+	return p.deriveDatatype(value)
+}
+
+func (p *SQL) createTable(table string, valuesMap map[string]interface{}) error {
+	d := dialectFor(p.Driver)
+
+	columns := make([]string, 0, len(valuesMap))
+	for name, value := range valuesMap {
+		if name == "metric" {
+			continue
+		}
+		columns = append(columns, fmt.Sprintf("%s %s", d.quoteIdent(name), p.columnDatatype(name, value)))
+	}
+	sort.Strings(columns)
+
+	ddl := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", d.quoteIdent(table), strings.Join(columns, ", "))
+
+	p.connMu.RLock()
+	db := p.db
+	p.connMu.RUnlock()
+
+	_, err := db.Exec(ddl)
+	return err
+}
+
+func (p *SQL) addColumns(table string, names []string, valuesMap map[string]interface{}) error {
+	d := dialectFor(p.Driver)
+
+	p.connMu.RLock()
+	db := p.db
+	p.connMu.RUnlock()
+
+	sort.Strings(names)
+	for _, name := range names {
+		if name == "metric" {
+			continue
+		}
+		ddl := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", d.quoteIdent(table), d.quoteIdent(name), p.columnDatatype(name, valuesMap[name]))
+		if _, err := db.Exec(ddl); err != nil {
+			return fmt.Errorf("adding column %q: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -2,32 +2,48 @@
 package template_sql
 
 import (
+	"context"
 	gosql "database/sql"
 	_ "embed"
+	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	//Register sql drivers
 	_ "github.com/ClickHouse/clickhouse-go" // clickhouse
 	_ "github.com/go-sql-driver/mysql"      // mysql
-	_ "github.com/jackc/pgx/v4/stdlib"      // pgx (postgres)
+	_ "github.com/jackc/pgx/v4/stdlib"      // pgx (postgres, database/sql path)
 	_ "github.com/microsoft/go-mssqldb"     // mssql (sql server)
 	_ "github.com/snowflakedb/gosnowflake"  // snowflake
 
 	// Register integrated auth for mssql
 	_ "github.com/microsoft/go-mssqldb/integratedauth/krb5"
 
+	// Native pgx fast path for the "pgx" driver (bypasses database/sql)
+	pgxv5 "github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
 
 //go:embed sample.conf
 var sampleConfig string
 
+// Only the last VALUES (...) clause of a query template may be batched into
+// a multi-row insert; everything before it is reused as-is for every row.
+var valuesClauseRegexp = regexp.MustCompile(`(?i)VALUES\s*\(([^)]*)\)\s*$`)
+
+// Matches a single-row "INSERT INTO table (cols...) VALUES (placeholders...)"
+// statement so the pgx native path can stream rows with COPY FROM instead.
+var insertColumnsRegexp = regexp.MustCompile(`(?i)^\s*INSERT\s+INTO\s+([a-zA-Z0-9_."]+)\s*\(([^)]*)\)\s*VALUES\s*\([^)]*\)\s*$`)
+
 type ConvertStruct struct {
 	Integer         string `toml:"integer"`
 	Real            string `toml:"real"`
@@ -39,6 +55,29 @@ type ConvertStruct struct {
 	ConversionStyle string `toml:"conversion_style"`
 }
 
+// pendingBatch accumulates the rows rendered for a single query template
+// until it is large enough, or old enough, to flush. idxs tracks, for each
+// buffered row, the index (within the current Write call) of the metric
+// that produced it, so a failed flush can tell Write which metrics to
+// leave out of its accepted set.
+type pendingBatch struct {
+	query string
+	rows  [][]any
+	idxs  []int
+}
+
+// batchFlushError wraps a failed batch flush together with the indices (set
+// by enqueue, relative to the Write call in progress) of every metric whose
+// row was part of the failed batch, so Write can exclude exactly those
+// metrics from the set it reports back to telegraf as accepted.
+type batchFlushError struct {
+	err  error
+	idxs []int
+}
+
+func (e *batchFlushError) Error() string { return e.err.Error() }
+func (e *batchFlushError) Unwrap() error { return e.err }
+
 type SQL struct {
 	Driver                string          `toml:"driver"`
 	DataSourceName        string          `toml:"data_source_name"`
@@ -50,9 +89,88 @@ type SQL struct {
 	ConnectionMaxLifetime config.Duration `toml:"connection_max_lifetime"`
 	ConnectionMaxIdle     int             `toml:"connection_max_idle"`
 	ConnectionMaxOpen     int             `toml:"connection_max_open"`
-	Log                   telegraf.Logger `toml:"-"`
 
-	db *gosql.DB
+	BatchSize         int             `toml:"batch_size"`
+	FlushInterval     config.Duration `toml:"flush_interval"`
+	MaxPendingBatches int             `toml:"max_pending_batches"`
+	BatchBy           string          `toml:"batch_by"`
+
+	// CopyFrom enables the native pgx COPY FROM fast path for driver = "pgx"
+	// when a query template is a simple single-row insert.
+	CopyFrom bool `toml:"copy_from"`
+
+	// Schema-sync options: when TableTemplate is set, the plugin can create
+	// and evolve the destination table itself instead of requiring
+	// hand-written DDL.
+	TableTemplate   string `toml:"table_template"`
+	AutoCreateTable bool   `toml:"auto_create_table"`
+	AutoAddColumns  bool   `toml:"auto_add_columns"`
+	ReadOnly        bool   `toml:"read_only"`
+
+	// Structured connection fields, used to build data_source_name when it
+	// is left empty.
+	Server   string `toml:"server"`
+	Port     int    `toml:"port"`
+	Username string `toml:"username"`
+	Password string `toml:"password"`
+	Database string `toml:"database"`
+
+	AuthMethod     string `toml:"auth_method"`
+	Krb5ConfFile   string `toml:"krb5_conf_file"`
+	Krb5Realm      string `toml:"krb5_realm"`
+	Krb5KeytabFile string `toml:"krb5_keytab_file"`
+	Krb5Username   string `toml:"krb5_username"`
+
+	TLSCert            string `toml:"tls_cert"`
+	TLSKey             string `toml:"tls_key"`
+	TLSCA              string `toml:"tls_ca"`
+	InsecureSkipVerify bool   `toml:"insecure_skip_verify"`
+	TLSServerName      string `toml:"tls_server_name"`
+
+	// Generated-upsert mode: when Table is set, the plugin builds its own
+	// upsert statement instead of using Queries.
+	Table           string   `toml:"table"`
+	TagColumns      []string `toml:"tag_columns"`
+	FieldColumns    []string `toml:"field_columns"`
+	TimestampColumn string   `toml:"timestamp_column"`
+	ConflictKey     []string `toml:"conflict_key"`
+
+	RetryAttempts    int             `toml:"retry_attempts"`
+	RetryBackoff     config.Duration `toml:"retry_backoff"`
+	QueryTimeout     config.Duration `toml:"query_timeout"`
+	ReconnectOnError bool            `toml:"reconnect_on_error"`
+
+	Log telegraf.Logger `toml:"-"`
+
+	// connMu guards db and pgxPool themselves (as opposed to the connections
+	// they hand out), so reconnect can swap them out without racing the
+	// background flusher and concurrent Write calls reading them.
+	connMu sync.RWMutex
+	db     *gosql.DB
+
+	// reconnectMu serializes reconnect() so two concurrent transient
+	// failures (e.g. a Write call and the background flusher hitting the
+	// same outage) don't each open a replacement pool and race to win
+	// p.db/p.pgxPool, orphaning whichever one loses.
+	reconnectMu sync.Mutex
+
+	// pgxPool is only populated for driver = "pgx"; it gives that driver a
+	// native fast path that bypasses database/sql entirely.
+	pgxPool *pgxpool.Pool
+
+	mu        sync.Mutex
+	batches   map[string]*pendingBatch
+	flushStop chan struct{}
+	flushWG   sync.WaitGroup
+
+	// schemaCache tracks, per table, which columns are known to exist so
+	// createTable/addColumns are only issued once per new table or column.
+	schemaMu    sync.Mutex
+	schemaCache map[string]map[string]bool
+
+	// upsertQuery is the generated statement used in place of Queries when
+	// Table is configured; it is built once in Connect.
+	upsertQuery string
 }
 
 func (*SQL) SampleConfig() string {
@@ -60,35 +178,242 @@ func (*SQL) SampleConfig() string {
 }
 
 func (p *SQL) Connect() error {
-	db, err := gosql.Open(p.Driver, p.DataSourceName)
-	if err != nil {
-		return err
+	if p.DataSourceName == "" {
+		dsn, err := p.buildDSN()
+		if err != nil {
+			return fmt.Errorf("building data_source_name: %w", err)
+		}
+		p.DataSourceName = dsn
 	}
 
-	err = db.Ping()
-	if err != nil {
+	if err := p.openConnections(); err != nil {
 		return err
 	}
 
-	db.SetConnMaxIdleTime(time.Duration(p.ConnectionMaxIdleTime))
-	db.SetConnMaxLifetime(time.Duration(p.ConnectionMaxLifetime))
-	db.SetMaxIdleConns(p.ConnectionMaxIdle)
-	db.SetMaxOpenConns(p.ConnectionMaxOpen)
-
 	if p.InitSQL != "" {
-		_, err = db.Exec(p.InitSQL)
+		if _, err := p.db.Exec(p.InitSQL); err != nil {
+			return err
+		}
+	}
+
+	p.schemaCache = make(map[string]map[string]bool)
+
+	if p.Table != "" {
+		query, err := p.buildUpsertQuery()
+		if err != nil {
+			return err
+		}
+		p.upsertQuery = query
+
+		if p.batchingEnabled() && !upsertBatchable(p.Driver) {
+			return fmt.Errorf("batch_size > 1 is not supported together with table/conflict_key for driver %q: "+
+				"its generated upsert statement has no trailing VALUES(...) clause to expand into a multi-row insert", p.Driver)
+		}
+	}
+
+	if p.batchingEnabled() {
+		p.batches = make(map[string]*pendingBatch)
+		p.flushStop = make(chan struct{})
+		if time.Duration(p.FlushInterval) > 0 {
+			p.flushWG.Add(1)
+			go p.runFlusher()
+		}
+	}
+
+	return nil
+}
+
+// openConnections opens (or re-opens) the database/sql pool and, for
+// driver = "pgx", the native pgx pool. It is shared by Connect and by
+// reconnect, which calls it again after a transient failure. The
+// database/sql pool is skipped for driver = "pgx" unless init_sql or
+// table_template need it, since otherwise the native pgx pool handles the
+// entire read/write path on its own and a second pool would just sit idle.
+func (p *SQL) openConnections() error {
+	var db *gosql.DB
+	if p.Driver != "pgx" || p.InitSQL != "" || p.TableTemplate != "" {
+		var err error
+		db, err = gosql.Open(p.Driver, p.DataSourceName)
 		if err != nil {
 			return err
 		}
+
+		if err := db.Ping(); err != nil {
+			return err
+		}
+
+		db.SetConnMaxIdleTime(time.Duration(p.ConnectionMaxIdleTime))
+		db.SetConnMaxLifetime(time.Duration(p.ConnectionMaxLifetime))
+		db.SetMaxIdleConns(p.ConnectionMaxIdle)
+		db.SetMaxOpenConns(p.ConnectionMaxOpen)
 	}
 
+	var pool *pgxpool.Pool
+	if p.Driver == "pgx" {
+		var err error
+		pool, err = pgxpool.New(context.Background(), p.DataSourceName)
+		if err != nil {
+			return fmt.Errorf("opening native pgx pool failed: %w", err)
+		}
+	}
+
+	p.connMu.Lock()
 	p.db = db
+	p.pgxPool = pool
+	p.connMu.Unlock()
 
 	return nil
 }
 
 func (p *SQL) Close() error {
-	return p.db.Close()
+	if p.batchingEnabled() {
+		if p.flushStop != nil {
+			close(p.flushStop)
+			p.flushWG.Wait()
+		}
+		if err := p.flushAll(); err != nil {
+			p.Log.Errorf("draining pending batches: %v", err)
+		}
+	}
+
+	p.connMu.RLock()
+	defer p.connMu.RUnlock()
+
+	if p.pgxPool != nil {
+		p.pgxPool.Close()
+	}
+	if p.db != nil {
+		return p.db.Close()
+	}
+	return nil
+}
+
+func (p *SQL) batchingEnabled() bool {
+	return p.BatchSize > 1
+}
+
+// runFlusher periodically drains every pending batch so rows accumulated
+// across Write calls (see enqueue) don't wait indefinitely for batch_size to
+// be reached. Its metrics were already reported accepted to telegraf by
+// whichever earlier Write call buffered them, so a failed flush here has no
+// telegraf-side retry to fall back on; flushKey re-queues the batch on
+// failure instead of dropping it; this only logs so the next tick's retry
+// is visible.
+func (p *SQL) runFlusher() {
+	defer p.flushWG.Done()
+
+	ticker := time.NewTicker(time.Duration(p.FlushInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.flushAll(); err != nil {
+				p.Log.Errorf("periodic flush failed, will retry on next flush_interval tick: %v", err)
+			}
+		case <-p.flushStop:
+			return
+		}
+	}
+}
+
+// batchKey groups together the rows that a single execBatch call will
+// flush as one multi-row statement.
+func (p *SQL) batchKey(renderedSQL, metricName string) string {
+	if p.BatchBy == "metric" {
+		return metricName + "\x00" + renderedSQL
+	}
+	return renderedSQL
+}
+
+// enqueue buffers a rendered row, tagged with the index (within the current
+// Write call) of the metric it came from, and flushes its batch once it is
+// full, or once the plugin is holding more distinct pending batches than
+// allowed. It returns the key the row was buffered under so the caller can
+// make sure that key is flushed before it reports the metric as accepted.
+func (p *SQL) enqueue(renderedSQL string, values []any, metricName string, idx int) (string, error) {
+	key := p.batchKey(renderedSQL, metricName)
+
+	p.mu.Lock()
+	b, ok := p.batches[key]
+	if !ok {
+		b = &pendingBatch{query: renderedSQL}
+		p.batches[key] = b
+	}
+	b.rows = append(b.rows, values)
+	b.idxs = append(b.idxs, idx)
+	rows := len(b.rows)
+	pending := len(p.batches)
+	p.mu.Unlock()
+
+	p.Log.Debugf("metric_batch_size for %q is now %d row(s) across %d pending batch(es)", renderedSQL, rows, pending)
+
+	if rows >= p.BatchSize || (p.MaxPendingBatches > 0 && pending > p.MaxPendingBatches) {
+		return key, p.flushKey(key)
+	}
+	return key, nil
+}
+
+// flushKey flushes the batch buffered under key, if any. On failure the
+// batch is put back under key (see requeue) instead of being dropped: by
+// the time a batch reaches its flush, the metrics that fed it have already
+// been reported accepted to telegraf (possibly several Write calls ago, via
+// the periodic flusher), so there is no telegraf-side retry left to recover
+// a batch this function discards. It still returns a *batchFlushError
+// carrying the metric indices (see pendingBatch) that were part of the
+// failed attempt, so a threshold-triggered flush from enqueue can tell
+// Write which metrics from *this* call to reject, even though the rows
+// themselves live on for a later retry rather than being lost.
+func (p *SQL) flushKey(key string) error {
+	p.mu.Lock()
+	b, ok := p.batches[key]
+	if ok {
+		delete(p.batches, key)
+	}
+	p.mu.Unlock()
+
+	if !ok || len(b.rows) == 0 {
+		return nil
+	}
+	if err := p.execBatch(b.query, b.rows); err != nil {
+		p.requeue(key, b)
+		return &batchFlushError{err: err, idxs: b.idxs}
+	}
+	return nil
+}
+
+// requeue puts a batch that failed to flush back under key so a later
+// flush retries it, merging in any rows a concurrent enqueue call buffered
+// under the same key while the failed flush was in flight.
+func (p *SQL) requeue(key string, failed *pendingBatch) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if current, ok := p.batches[key]; ok {
+		failed.rows = append(failed.rows, current.rows...)
+		failed.idxs = append(failed.idxs, current.idxs...)
+	}
+	p.batches[key] = failed
+}
+
+// flushAll drains every currently pending batch, continuing on to the rest
+// even if one key's flush fails (and gets requeued by flushKey) so a single
+// stuck batch can't stall every other template's flush on this tick.
+func (p *SQL) flushAll() error {
+	p.mu.Lock()
+	keys := make([]string, 0, len(p.batches))
+	for key := range p.batches {
+		keys = append(keys, key)
+	}
+	p.mu.Unlock()
+
+	var lastErr error
+	for _, key := range keys {
+		if err := p.flushKey(key); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
 }
 
 // Quote an identifier (table or column name)
@@ -108,7 +433,7 @@ func sanitizeQuoted(in string) string {
 	// Whitelist allowed characters
 	return strings.Map(func(r rune) rune {
 		switch {
-		case r >= '\u0001' && r <= '\uFFFF':
+		case r >= '' && r <= '￿':
 			return r
 		default:
 			return '_'
@@ -174,72 +499,337 @@ func (p *SQL) generateQuery(sql string, valuesMap map[string]interface{}) (strin
 	return sql, values, nil
 }
 
-func (p *SQL) WriteMetric(metric telegraf.Metric) error {
-	for _, query := range p.Queries {
-
-		valuesMap := make(map[string]any)
+// buildMultiRowValues expands a single-row "... VALUES (p1,p2,...)" template
+// into a multi-row "... VALUES (p1,p2,...),(p1,p2,...),..." statement
+// covering every buffered row, renumbering placeholders as needed.
+func (p *SQL) buildMultiRowValues(sql string, rows [][]any) (string, []any, error) {
+	loc := valuesClauseRegexp.FindStringSubmatchIndex(sql)
+	if loc == nil {
+		return "", nil, fmt.Errorf("query %q does not end in a single VALUES(...) clause required for batching", sql)
+	}
 
-		valuesMap["metric"] = metric.Name()
-		valuesMap["timestamp"] = metric.Time()
+	placeholderCount := len(strings.Split(sql[loc[2]:loc[3]], ","))
 
-		for key, value := range p.DefaultValues {
-			valuesMap[key] = value
+	groups := make([]string, 0, len(rows))
+	values := make([]any, 0, len(rows)*placeholderCount)
+	counter := 0
+	for _, row := range rows {
+		if len(row) != placeholderCount {
+			return "", nil, fmt.Errorf("row has %d value(s), expected %d", len(row), placeholderCount)
 		}
+		placeholders := make([]string, placeholderCount)
+		for i := range placeholders {
+			if p.Driver == "pgx" {
+				counter++
+				placeholders[i] = "$" + strconv.Itoa(counter)
+			} else {
+				placeholders[i] = "?"
+			}
+		}
+		groups = append(groups, "("+strings.Join(placeholders, ",")+")")
+		values = append(values, row...)
+	}
 
-		for tag, value := range metric.Tags() {
-			valuesMap[tag] = value
+	return sql[:loc[0]] + "VALUES " + strings.Join(groups, ","), values, nil
+}
+
+// execBatch flushes every buffered row for one query template as a single
+// multi-row statement (or, for ClickHouse, a single prepared-statement batch
+// inside one transaction).
+func (p *SQL) execBatch(query string, rows [][]any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	return p.withRetry(func() error { return p.execBatchOnce(query, rows) })
+}
+
+func (p *SQL) execBatchOnce(query string, rows [][]any) error {
+	ctx, cancel := p.queryContext()
+	defer cancel()
+
+	p.connMu.RLock()
+	db, pgxPool := p.db, p.pgxPool
+	p.connMu.RUnlock()
+
+	if p.Driver == "pgx" && pgxPool != nil {
+		return p.execPgxBatch(ctx, pgxPool, query, rows)
+	}
+
+	switch p.Driver {
+	case "clickhouse":
+		// ClickHouse needs to batch inserts with prepared statements
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("begin failed: %w", err)
+		}
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("prepare failed: %w", err)
 		}
+		defer stmt.Close() //nolint:revive,gocritic // done on purpose, closing will be executed properly
 
-		for field, value := range metric.Fields() {
-			valuesMap[field] = value
+		for _, values := range rows {
+			if _, err := stmt.ExecContext(ctx, values...); err != nil {
+				return fmt.Errorf("execution failed: %w", err)
+			}
 		}
 
-		sql, values, err := p.generateQuery(query, valuesMap)
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("commit failed: %w", err)
+		}
+	default:
+		multiSQL, values, err := p.buildMultiRowValues(query, rows)
 		if err != nil {
 			return err
 		}
+		if _, err := db.ExecContext(ctx, multiSQL, values...); err != nil {
+			return fmt.Errorf("execution failed: %w", err)
+		}
+	}
+	return nil
+}
 
-		switch p.Driver {
-		case "clickhouse":
-			// ClickHouse needs to batch inserts with prepared statements
-			tx, err := p.db.Begin()
-			if err != nil {
-				return fmt.Errorf("begin failed: %w", err)
-			}
-			stmt, err := tx.Prepare(sql)
-			if err != nil {
-				return fmt.Errorf("prepare failed: %w", err)
-			}
-			defer stmt.Close() //nolint:revive,gocritic // done on purpose, closing will be executed properly
+// execSingle runs one rendered query/value pair immediately; it is the
+// un-batched code path used when batch_size <= 1.
+func (p *SQL) execSingle(query string, values []any) error {
+	return p.withRetry(func() error { return p.execSingleOnce(query, values) })
+}
 
-			_, err = stmt.Exec(values...)
-			if err != nil {
-				return fmt.Errorf("execution failed: %w", err)
-			}
-			err = tx.Commit()
-			if err != nil {
-				return fmt.Errorf("commit failed: %w", err)
-			}
+func (p *SQL) execSingleOnce(query string, values []any) error {
+	ctx, cancel := p.queryContext()
+	defer cancel()
+
+	p.connMu.RLock()
+	db, pgxPool := p.db, p.pgxPool
+	p.connMu.RUnlock()
+
+	if p.Driver == "pgx" && pgxPool != nil {
+		return p.execPgxSingle(ctx, pgxPool, query, values)
+	}
+
+	switch p.Driver {
+	case "clickhouse":
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("begin failed: %w", err)
+		}
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("prepare failed: %w", err)
+		}
+		defer stmt.Close() //nolint:revive,gocritic // done on purpose, closing will be executed properly
+
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			return fmt.Errorf("execution failed: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("commit failed: %w", err)
+		}
+	default:
+		if _, err := db.ExecContext(ctx, query, values...); err != nil {
+			return fmt.Errorf("execution failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// parseSimpleInsert extracts the table and column list from a rendered
+// "INSERT INTO table (cols...) VALUES (...)" statement, returning ok=false
+// for anything else so callers can fall back to a regular prepared exec.
+// table is split into its schema-qualification parts (e.g. "public",
+// "metrics" for public.metrics or "public"."metrics") so callers can address
+// it as a multi-part identifier rather than a single dotted name.
+func parseSimpleInsert(query string) (table []string, columns []string, ok bool) {
+	m := insertColumnsRegexp.FindStringSubmatch(query)
+	if m == nil {
+		return nil, nil, false
+	}
+
+	for _, col := range strings.Split(m[2], ",") {
+		columns = append(columns, strings.Trim(strings.TrimSpace(col), `"`))
+	}
+	return splitQualifiedIdentifier(strings.TrimSpace(m[1])), columns, true
+}
+
+// splitQualifiedIdentifier splits a possibly quoted, dot-qualified
+// identifier such as public.metrics or "public"."metrics" into its parts,
+// stripping the quotes. A bare "." inside a quoted part is kept as-is.
+func splitQualifiedIdentifier(raw string) []string {
+	var parts []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range raw {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == '.' && !quoted:
+			parts = append(parts, current.String())
+			current.Reset()
 		default:
-			_, err = p.db.Exec(sql, values...)
-			if err != nil {
-				return fmt.Errorf("execution failed: %w", err)
-			}
+			current.WriteRune(r)
 		}
 	}
+	return append(parts, current.String())
+}
+
+// pgxCopyFrom streams rows into table via the binary COPY protocol, which is
+// considerably faster than a parameterized INSERT for bulk loads. table's
+// parts (schema, name) are passed through as a multi-part pgx.Identifier so
+// a schema-qualified table is quoted as two names rather than one name that
+// happens to contain a dot.
+func (p *SQL) pgxCopyFrom(ctx context.Context, pgxPool *pgxpool.Pool, table []string, columns []string, rows [][]any) error {
+	_, err := pgxPool.CopyFrom(ctx, pgxv5.Identifier(table), columns, pgxv5.CopyFromRows(rows))
+	if err != nil {
+		return fmt.Errorf("copy from failed: %w", err)
+	}
 	return nil
 }
 
-func (p *SQL) Write(metrics []telegraf.Metric) error {
-	for _, metric := range metrics {
-		err := p.WriteMetric(metric)
+// execPgxSingle runs one rendered query/value pair against the native pgx
+// pool, using COPY FROM when copy_from is enabled and the query is a simple
+// single-row insert.
+func (p *SQL) execPgxSingle(ctx context.Context, pgxPool *pgxpool.Pool, query string, values []any) error {
+	if p.CopyFrom {
+		if table, columns, ok := parseSimpleInsert(query); ok {
+			return p.pgxCopyFrom(ctx, pgxPool, table, columns, [][]any{values})
+		}
+	}
+
+	if _, err := pgxPool.Exec(ctx, query, values...); err != nil {
+		return fmt.Errorf("execution failed: %w", err)
+	}
+	return nil
+}
+
+// execPgxBatch flushes a buffered set of rows for one query template against
+// the native pgx pool: COPY FROM when copy_from applies, otherwise a pgx
+// pipelined batch of the prepared exec.
+func (p *SQL) execPgxBatch(ctx context.Context, pgxPool *pgxpool.Pool, query string, rows [][]any) error {
+	if p.CopyFrom {
+		if table, columns, ok := parseSimpleInsert(query); ok {
+			return p.pgxCopyFrom(ctx, pgxPool, table, columns, rows)
+		}
+	}
+
+	batch := &pgxv5.Batch{}
+	for _, values := range rows {
+		batch.Queue(query, values...)
+	}
+
+	br := pgxPool.SendBatch(ctx, batch)
+	defer br.Close()
+
+	for range rows {
+		if _, err := br.Exec(); err != nil {
+			return fmt.Errorf("execution failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// WriteMetric renders and executes (or, when batching is enabled, enqueues)
+// every query for metric. idx identifies metric's position in the Write call
+// it belongs to; it is only used to tag buffered rows (see enqueue) so that,
+// if enqueue's own threshold-triggered flush fails, the batchFlushError it
+// returns can tell Write exactly which metrics to reject.
+func (p *SQL) WriteMetric(metric telegraf.Metric, idx int) error {
+	valuesMap := make(map[string]any)
+
+	valuesMap["metric"] = metric.Name()
+	valuesMap["timestamp"] = metric.Time()
+
+	for key, value := range p.DefaultValues {
+		valuesMap[key] = value
+	}
+
+	for tag, value := range metric.Tags() {
+		valuesMap[tag] = value
+	}
+
+	for field, value := range metric.Fields() {
+		valuesMap[field] = value
+	}
+
+	if p.TableTemplate != "" && (p.AutoCreateTable || p.AutoAddColumns) {
+		table := p.renderTable(valuesMap)
+		if err := p.ensureSchema(table, valuesMap); err != nil {
+			return err
+		}
+	}
+
+	queries := p.Queries
+	if p.Table != "" {
+		queries = []string{p.upsertQuery}
+	}
+
+	for _, query := range queries {
+		sql, values, err := p.generateQuery(query, valuesMap)
 		if err != nil {
 			return err
 		}
+
+		if p.batchingEnabled() {
+			if _, err := p.enqueue(sql, values, metric.Name(), idx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := p.execSingle(sql, values); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+// Write hands every metric to WriteMetric. When batching is enabled, a
+// metric whose row was only buffered — its batch hadn't yet reached
+// batch_size or max_pending_batches — is accepted as soon as it's enqueued;
+// actually getting it to the database is left to a later Write call's
+// threshold-triggered flush, or to the background flusher once
+// flush_interval elapses. That keeps batches able to accumulate across
+// Write calls instead of being force-drained every time (which would make
+// flush_interval and max_pending_batches pointless). If enqueue's own
+// threshold flush fails, batchFlushError tells us exactly which metrics
+// were in that batch so they, specifically, are rejected; a failure from
+// the background flusher has no Write call left to report to, so it is
+// only logged (see runFlusher).
+func (p *SQL) Write(metrics []telegraf.Metric) error {
+	accepted := make([]int, 0, len(metrics))
+	rejected := make(map[int]bool)
+	var lastErr error
+
+	for i, metric := range metrics {
+		if err := p.WriteMetric(metric, i); err != nil {
+			lastErr = err
+			rejected[i] = true
+			var flushErr *batchFlushError
+			if errors.As(err, &flushErr) {
+				for _, idx := range flushErr.idxs {
+					rejected[idx] = true
+				}
+			}
+			continue
+		}
+		accepted = append(accepted, i)
+	}
+
+	if lastErr == nil {
+		return nil
+	}
+
+	if len(rejected) > 0 {
+		filtered := accepted[:0]
+		for _, i := range accepted {
+			if !rejected[i] {
+				filtered = append(filtered, i)
+			}
+		}
+		accepted = filtered
+	}
+	return &internal.PartialWriteError{Err: lastErr, MetricsAccept: accepted}
+}
+
 func init() {
 	outputs.Add("template_sql", func() telegraf.Output { return newSQL() })
 }
@@ -262,5 +852,14 @@ func newSQL() *SQL {
 		// except max idle connections which is 2. See
 		// https://pkg.go.dev/database/sql#DB.SetMaxIdleConns
 		ConnectionMaxIdle: 2,
+
+		// Batching is off by default (one Exec per query per metric); set
+		// batch_size > 1 to enable buffered multi-row writes.
+		BatchSize:         1,
+		MaxPendingBatches: 1000,
+		BatchBy:           "template",
+
+		// A single attempt reproduces the previous, non-retrying behavior.
+		RetryAttempts: 1,
 	}
 }
@@ -0,0 +1,123 @@
+package template_sql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// upsertBatchable reports whether a driver's generated upsert statement ends
+// in a single "VALUES(...)" clause that execBatchOnce's default path can
+// expand into a multi-row insert. pgx goes through its own native pipelined
+// batch instead, and plain clickhouse inserts already satisfy the shape, so
+// only the ON DUPLICATE KEY UPDATE / MERGE dialects need to be excluded.
+func upsertBatchable(driver string) bool {
+	switch driver {
+	case "mysql", "mssql", "snowflake":
+		return false
+	default:
+		return true
+	}
+}
+
+// buildUpsertQuery generates a driver-appropriate upsert statement from the
+// declarative table/tag_columns/field_columns/timestamp_column/conflict_key
+// configuration, so the user doesn't have to hand-write five SQL dialects.
+// The timestamp column, if any, is always bound to the metric's synthetic
+// ":timestamp" value; every other column name doubles as its placeholder.
+func (p *SQL) buildUpsertQuery() (string, error) {
+	d := dialectFor(p.Driver)
+
+	columns := make([]string, 0, len(p.TagColumns)+len(p.FieldColumns)+1)
+	columns = append(columns, p.TagColumns...)
+	columns = append(columns, p.FieldColumns...)
+	if p.TimestampColumn != "" {
+		columns = append(columns, p.TimestampColumn)
+	}
+
+	quotedColumns := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	for i, col := range columns {
+		quotedColumns[i] = d.quoteIdent(col)
+		if col == p.TimestampColumn {
+			placeholders[i] = ":timestamp"
+		} else {
+			placeholders[i] = ":" + col
+		}
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		d.quoteIdent(p.Table), strings.Join(quotedColumns, ","), strings.Join(placeholders, ","))
+
+	switch p.Driver {
+	case "pgx":
+		if len(p.ConflictKey) == 0 {
+			return insert, nil
+		}
+		conflictCols := make([]string, len(p.ConflictKey))
+		for i, col := range p.ConflictKey {
+			conflictCols[i] = d.quoteIdent(col)
+		}
+		assignments := make([]string, len(p.FieldColumns))
+		for i, col := range p.FieldColumns {
+			assignments[i] = fmt.Sprintf("%s = EXCLUDED.%s", d.quoteIdent(col), d.quoteIdent(col))
+		}
+		return fmt.Sprintf("%s ON CONFLICT (%s) DO UPDATE SET %s", insert, strings.Join(conflictCols, ","), strings.Join(assignments, ",")), nil
+
+	case "mysql":
+		assignments := make([]string, len(p.FieldColumns))
+		for i, col := range p.FieldColumns {
+			assignments[i] = fmt.Sprintf("%s = VALUES(%s)", d.quoteIdent(col), d.quoteIdent(col))
+		}
+		return fmt.Sprintf("%s ON DUPLICATE KEY UPDATE %s", insert, strings.Join(assignments, ",")), nil
+
+	case "mssql", "snowflake":
+		return p.buildMergeQuery(d, columns, placeholders), nil
+
+	case "clickhouse":
+		// ClickHouse has no native upsert; de-duplication needs a
+		// ReplacingMergeTree (or similar) table engine, with deduplication
+		// happening asynchronously during merges. Plain insert is correct
+		// here, the engine does the rest.
+		p.Log.Warnf("driver %q has no native upsert; use a ReplacingMergeTree table engine for deduplication on %v", p.Driver, p.ConflictKey)
+		return insert, nil
+
+	default:
+		return "", fmt.Errorf("generated upserts are not supported for driver %q", p.Driver)
+	}
+}
+
+// buildMergeQuery generates the MERGE statement MSSQL and Snowflake both use
+// in place of an ON CONFLICT/ON DUPLICATE KEY clause.
+func (p *SQL) buildMergeQuery(d dialect, columns, placeholders []string) string {
+	sourceCols := make([]string, len(columns))
+	for i, col := range columns {
+		sourceCols[i] = fmt.Sprintf("%s AS %s", placeholders[i], d.quoteIdent(col))
+	}
+
+	conditions := make([]string, len(p.ConflictKey))
+	for i, col := range p.ConflictKey {
+		ident := d.quoteIdent(col)
+		conditions[i] = fmt.Sprintf("target.%s = src.%s", ident, ident)
+	}
+
+	updates := make([]string, len(p.FieldColumns))
+	for i, col := range p.FieldColumns {
+		ident := d.quoteIdent(col)
+		updates[i] = fmt.Sprintf("target.%s = src.%s", ident, ident)
+	}
+
+	insertCols := make([]string, len(columns))
+	insertVals := make([]string, len(columns))
+	for i, col := range columns {
+		ident := d.quoteIdent(col)
+		insertCols[i] = ident
+		insertVals[i] = fmt.Sprintf("src.%s", ident)
+	}
+
+	return fmt.Sprintf(
+		"MERGE INTO %s AS target USING (SELECT %s) AS src ON (%s) "+
+			"WHEN MATCHED THEN UPDATE SET %s "+
+			"WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s);",
+		d.quoteIdent(p.Table), strings.Join(sourceCols, ","), strings.Join(conditions, " AND "),
+		strings.Join(updates, ","), strings.Join(insertCols, ","), strings.Join(insertVals, ","))
+}